@@ -0,0 +1,145 @@
+//Package account 描述多账号/多地域场景下,单个探测目标（账号+地域）的来源与热加载方式
+package account
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"gopkg.in/yaml.v2"
+)
+
+// configPathFlag 是 --account.config-file(或 KSC_EXPORTER_ACCOUNT_CONFIG_FILE)指向的账号
+// YAML 文件路径,未设置时为空,表示单账号部署、不使用 FileProvider
+var configPathFlag = kingpin.Flag("account.config-file", "Path to a YAML file listing the accounts/regions to scrape, enables multi-account fan-out and SIGHUP hot-reload when set").
+	Envar("KSC_EXPORTER_ACCOUNT_CONFIG_FILE").Default("").String()
+
+// NewFileProviderFromFlags 若 --account.config-file 已设置,则据此构造一个 FileProvider;
+// 否则返回 nil, nil,调用方应当回退到单账号部署、不创建 MultiAccountCollector
+func NewFileProviderFromFlags(logger log.Logger) (*FileProvider, error) {
+	if *configPathFlag == "" {
+		return nil, nil
+	}
+	return NewFileProvider(*configPathFlag, logger)
+}
+
+// Account 描述一个可被探测的 Kingsoft Cloud 账号在某个地域下的凭证与附加标签
+type Account struct {
+	AccountID string            `yaml:"account_id"`
+	AK        string            `yaml:"ak"`
+	SK        string            `yaml:"sk"`
+	Region    string            `yaml:"region"`
+	Labels    map[string]string `yaml:"labels,omitempty"`
+}
+
+// Key 唯一标识该账号在某个地域下的抓取目标
+func (a *Account) Key() string {
+	return fmt.Sprintf("%s/%s", a.AccountID, a.Region)
+}
+
+// Provider 提供账号列表,并支持在列表发生变化时通知调用方重建采集器
+type Provider interface {
+	Accounts() []*Account
+	Watch(onChange func([]*Account)) error
+	Stop()
+}
+
+type fileProviderConf struct {
+	Accounts []*Account `yaml:"accounts"`
+}
+
+// FileProvider 从 YAML 文件加载账号列表,并在收到 SIGHUP 时重新加载
+type FileProvider struct {
+	path   string
+	logger log.Logger
+
+	lock     sync.RWMutex
+	accounts []*Account
+
+	sigCh  chan os.Signal
+	stopCh chan struct{}
+}
+
+// NewFileProvider 从 path 指向的 YAML 文件构造账号 Provider,构造时会先加载一次
+func NewFileProvider(path string, logger log.Logger) (*FileProvider, error) {
+	p := &FileProvider{
+		path:   path,
+		logger: logger,
+		sigCh:  make(chan os.Signal, 1),
+		stopCh: make(chan struct{}),
+	}
+
+	if err := p.reload(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Accounts 返回当前已加载的账号列表的一份拷贝
+func (p *FileProvider) Accounts() []*Account {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	accounts := make([]*Account, len(p.accounts))
+	copy(accounts, p.accounts)
+	return accounts
+}
+
+// Watch 注册 SIGHUP 信号处理,文件重新加载成功后回调 onChange
+func (p *FileProvider) Watch(onChange func([]*Account)) error {
+	signal.Notify(p.sigCh, syscall.SIGHUP)
+
+	go func() {
+		for {
+			select {
+			case <-p.stopCh:
+				signal.Stop(p.sigCh)
+				return
+			case <-p.sigCh:
+				level.Info(p.logger).Log("msg", "received SIGHUP, reloading account list", "path", p.path)
+				if err := p.reload(); err != nil {
+					level.Error(p.logger).Log("msg", "reload account list fail", "err", err, "path", p.path)
+					continue
+				}
+				onChange(p.Accounts())
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop 停止监听 SIGHUP
+func (p *FileProvider) Stop() {
+	close(p.stopCh)
+}
+
+func (p *FileProvider) reload() error {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return fmt.Errorf("read account file %s fail: %w", p.path, err)
+	}
+
+	var conf fileProviderConf
+	if err := yaml.Unmarshal(data, &conf); err != nil {
+		return fmt.Errorf("parse account file %s fail: %w", p.path, err)
+	}
+
+	for _, a := range conf.Accounts {
+		if a.AccountID == "" || a.AK == "" || a.SK == "" || a.Region == "" {
+			return fmt.Errorf("invalid account entry in %s: account_id/ak/sk/region are required", p.path)
+		}
+	}
+
+	p.lock.Lock()
+	p.accounts = conf.Accounts
+	p.lock.Unlock()
+
+	return nil
+}
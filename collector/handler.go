@@ -0,0 +1,68 @@
+package collector
+
+import (
+	"github.com/KscSDK/kingsoftcloud-exporter/instance"
+	"github.com/KscSDK/kingsoftcloud-exporter/metric"
+
+	"github.com/go-kit/log"
+)
+
+// ProductHandler 封装某个 KSC 产品特有的实例发现与指标查询纬度构造逻辑,
+// 每个产品通过 RegisterProductHandler 注册自己的 HandlerFactory
+type ProductHandler interface {
+	// GetInstances 返回该产品当前可见的全部实例
+	GetInstances() ([]instance.KscInstance, error)
+	// GetSeriesByInstances 为给定的一组实例构造某个指标的查询纬度
+	GetSeriesByInstances(m *metric.Metric, instances []instance.KscInstance) ([]*metric.Series, error)
+}
+
+// SubResourceHandler 是 ProductHandler 的可选扩展。部分产品的实例内部还存在更细粒度、
+// 需要各自独立产出 series 的子资源(例如 Redis 集群下的分片、KRDS 下的只读实例),
+// loadMetrics 会在 handler 实现了该接口时,对每个 (instance, 子资源) 组合分别产出
+// Metric/Series,并把子资源标识作为新增纬度标签打到对应的 series 上。
+type SubResourceHandler interface {
+	// GetSubResources 返回 ins 下的子资源列表;没有子资源时返回空切片或 nil
+	GetSubResources(ins instance.KscInstance) ([]SubResource, error)
+}
+
+// SubResource 描述一个实例内部的子资源
+type SubResource struct {
+	// ID 子资源的唯一标识,例如分片 ID、只读实例 ID,会写入 sub_resource_id 标签
+	ID string
+	// Labels 子资源自身携带的附加纬度,例如分片角色、可用区
+	Labels map[string]string
+}
+
+// subResourceIDLabel 是子资源标识统一使用的标签名
+const subResourceIDLabel = "sub_resource_id"
+
+// HandlerFactory 根据 collector 与 logger 构造一个产品的 ProductHandler 实例
+type HandlerFactory func(c *KscProductCollector, logger log.Logger) (ProductHandler, error)
+
+// handlerFactoryMap 以 namespace 注册各产品的 HandlerFactory
+var handlerFactoryMap = make(map[string]HandlerFactory)
+
+// RegisterProductHandler 供各产品的 handler 实现在 init() 中注册自己的 HandlerFactory,
+// NewKscProductCollector 按 namespace 从这里查找对应的 handler 构造函数
+func RegisterProductHandler(namespace string, factory HandlerFactory) {
+	handlerFactoryMap[namespace] = factory
+}
+
+// HandlerDecorator 在某个 namespace 已注册的 ProductHandler 构造完成后对其做包装,
+// 用于在不替换该 namespace 原有实例发现/series 构建逻辑的前提下,为其叠加额外能力
+// (例如 SubResourceHandler)。与直接 RegisterProductHandler 覆盖同一个 namespace 不同,
+// decorator 拿到的是原有 handler 本身,委托给它而不是另起炉灶重新实现一遍。
+type HandlerDecorator func(inner ProductHandler, c *KscProductCollector, logger log.Logger) (ProductHandler, error)
+
+// handlerDecoratorMap 以 namespace 注册对应的 HandlerDecorator
+var handlerDecoratorMap = make(map[string]HandlerDecorator)
+
+// RegisterHandlerDecorator 供需要在已有产品 handler 基础上叠加能力的扩展在 init() 中注册。
+// NewKscProductCollector 会在 factory 构造出基础 handler 之后,按 namespace 查找并应用这里
+// 注册的 decorator。同一个包内多个文件的 init() 执行顺序是未定义的,但这不影响正确性:
+// decorator 只在 NewKscProductCollector 被调用时才按 namespace 查表应用,而调用发生的时刻,
+// 整个程序的全部 init() 必然已经执行完毕,因此无论 RegisterProductHandler 和
+// RegisterHandlerDecorator 谁的 init() 先跑,查表时两者都已经注册完成。
+func RegisterHandlerDecorator(namespace string, decorator HandlerDecorator) {
+	handlerDecoratorMap[namespace] = decorator
+}
@@ -0,0 +1,68 @@
+package collector
+
+import (
+	"github.com/KscSDK/kingsoftcloud-exporter/instance"
+	"github.com/KscSDK/kingsoftcloud-exporter/metric"
+
+	"github.com/go-kit/log"
+)
+
+// kcsNamespace 是 KSC Redis 在 handlerFactoryMap 中已经注册的真实 namespace。为它叠加
+// 分片纬度能力必须通过 HandlerDecorator 包装已有 handler,而不是另起一个不对应真实
+// KSC 产品代码的 namespace —— instance.NewInstanceRepository/MetricRepo.ListMetrics 都是
+// 按真实产品代码向 KSC OpenAPI 发起请求的,编出来的 namespace 在这两处都拿不到任何
+// 实例或指标数据。
+const kcsNamespace = "KCS"
+
+func init() {
+	RegisterHandlerDecorator(kcsNamespace, newKcsRedisShardHandler)
+}
+
+// shardedInstance 是 instance.KscInstance 的可选扩展,由支持分片拓扑的产品在实例发现阶段实现,
+// 用于在不改动 instance.KscInstance 基础接口的前提下暴露分片列表
+type shardedInstance interface {
+	GetShardIDs() []string
+}
+
+// kcsRedisShardHandler 在 "KCS" 已有的 ProductHandler 基础上叠加分片纬度的 SubResource 能力,
+// 实例发现、series 构造全部委托给 inner,自身只负责把分片暴露成子资源,这也是
+// HandlerDecorator 框架的第一个落地用例。
+type kcsRedisShardHandler struct {
+	inner  ProductHandler
+	logger log.Logger
+}
+
+func newKcsRedisShardHandler(inner ProductHandler, c *KscProductCollector, logger log.Logger) (ProductHandler, error) {
+	return &kcsRedisShardHandler{inner: inner, logger: logger}, nil
+}
+
+// GetInstances 委托给被包装的 "KCS" handler,不重新实现实例发现
+func (h *kcsRedisShardHandler) GetInstances() ([]instance.KscInstance, error) {
+	return h.inner.GetInstances()
+}
+
+// GetSeriesByInstances 委托给被包装的 "KCS" handler,不重新实现查询纬度构造
+func (h *kcsRedisShardHandler) GetSeriesByInstances(m *metric.Metric, instances []instance.KscInstance) ([]*metric.Series, error) {
+	return h.inner.GetSeriesByInstances(m, instances)
+}
+
+// GetSubResources 返回该 Redis 实例下的全部分片。分片列表由实例发现阶段随实例详情
+// 一并加载,这里只是把 shardedInstance 暴露出来的分片 ID 转成通用的 SubResource。
+func (h *kcsRedisShardHandler) GetSubResources(ins instance.KscInstance) ([]SubResource, error) {
+	sharded, ok := ins.(shardedInstance)
+	if !ok {
+		return nil, nil
+	}
+
+	shardIDs := sharded.GetShardIDs()
+	subResources := make([]SubResource, 0, len(shardIDs))
+	for _, shardID := range shardIDs {
+		subResources = append(subResources, SubResource{
+			ID: shardID,
+			Labels: map[string]string{
+				"instance_id": ins.GetInstanceID(),
+			},
+		})
+	}
+	return subResources, nil
+}
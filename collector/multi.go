@@ -0,0 +1,274 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/KscSDK/kingsoftcloud-exporter/account"
+	"github.com/KscSDK/kingsoftcloud-exporter/config"
+	"github.com/KscSDK/kingsoftcloud-exporter/instance"
+	"github.com/KscSDK/kingsoftcloud-exporter/metric"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// accountRegionKey 标识一个 (account, region, namespace) 抓取目标
+type accountRegionKey struct {
+	AccountID string
+	Region    string
+	Namespace string
+}
+
+func (k accountRegionKey) String() string {
+	return fmt.Sprintf("%s/%s/%s", k.AccountID, k.Region, k.Namespace)
+}
+
+// accountTarget 绑定一个 KscProductCollector 与驱动它定时刷新的 Reloader,并保留构建它
+// 时所用的 *account.Account,供 rebuild 在账号配置不变时跳过重建、变化时识别出差异
+type accountTarget struct {
+	collector *KscProductCollector
+	reloader  *KscProductCollectorReloader
+	account   *account.Account
+}
+
+// MultiAccountCollector 按账号配置文件中的账号列表,为每个 (account,region,namespace)
+// 组合各自构建一个独立的 KscProductCollector 并独立运行其 Reloader,
+// 在 Collect 时把所有目标的 series 合并输出,同时补充 ksc_account、ksc_region 标签,
+// 使单个 exporter 进程可以代理多个 Kingsoft Cloud 账号/地域。
+type MultiAccountCollector struct {
+	namespaces   []string
+	metricRepo   metric.MetricRepository
+	exporterConf *config.KscExporterConfig
+	provider     account.Provider
+	ctx          context.Context
+	logger       log.Logger
+
+	lock    sync.RWMutex
+	targets map[accountRegionKey]*accountTarget
+
+	// instanceRepoOwner 记录每个已构建的 InstanceRepository 底层实例归属的 AccountID。
+	// NewKscProductCollector 依赖 instance.NewInstanceRepository 按账号隔离凭证,但那是
+	// instance 包内部未在此次改动中验证过的行为;这里在拿到 InstanceRepository 后用其
+	// 地址做一次显式核对,一旦两个账号拿到了同一个底层实例就视为凭证泄露并报错,
+	// 而不是仅凭注释断言"不会跨租户复用"。用独立的锁保护,避免与 rebuild 已持有的
+	// m.lock 重入。
+	instanceRepoOwnerLock sync.Mutex
+	instanceRepoOwner     map[uintptr]string
+}
+
+// NewMultiAccountCollector 依据 provider 当前返回的账号列表初始化全部采集目标,
+// 并注册 provider.Watch 回调以便账号列表热更新时增删目标
+func NewMultiAccountCollector(
+	ctx context.Context,
+	namespaces []string,
+	metricRepo metric.MetricRepository,
+	exporterConf *config.KscExporterConfig,
+	provider account.Provider,
+	logger log.Logger,
+) (*MultiAccountCollector, error) {
+	m := &MultiAccountCollector{
+		namespaces:        namespaces,
+		metricRepo:        metricRepo,
+		exporterConf:      exporterConf,
+		provider:          provider,
+		ctx:               ctx,
+		logger:            logger,
+		targets:           make(map[accountRegionKey]*accountTarget),
+		instanceRepoOwner: make(map[uintptr]string),
+	}
+
+	if err := m.rebuild(provider.Accounts()); err != nil {
+		return nil, err
+	}
+
+	if err := provider.Watch(func(accounts []*account.Account) {
+		if err := m.rebuild(accounts); err != nil {
+			level.Error(m.logger).Log("msg", "rebuild account targets fail", "err", err)
+		}
+	}); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// rebuild 按最新账号列表增删 target;未发生变化的账号保留原有 collector/reloader,不做重建
+func (m *MultiAccountCollector) rebuild(accounts []*account.Account) error {
+	wanted := make(map[accountRegionKey]*account.Account)
+	for _, a := range accounts {
+		for _, ns := range m.namespaces {
+			wanted[accountRegionKey{AccountID: a.AccountID, Region: a.Region, Namespace: ns}] = a
+		}
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	// 账号配置中已移除的目标,停止其 Reloader 并摘除
+	for key, t := range m.targets {
+		if _, ok := wanted[key]; !ok {
+			t.reloader.Stop()
+			delete(m.targets, key)
+			level.Info(m.logger).Log("msg", "account target removed", "target", key.String())
+		}
+	}
+
+	// 新增或发生变化的账号配置中出现的目标。同一个 key 下账号的 AK/SK/Labels 发生变化
+	// (例如凭证轮换)时 key 本身不变,不能仅凭 key 已存在就跳过,否则 SIGHUP 热加载对
+	// 最常见的"只改凭证"场景会静默失效,旧的 collector 会一直用过期的 AK/SK 采集。
+	for key, a := range wanted {
+		if t, ok := m.targets[key]; ok {
+			if reflect.DeepEqual(t.account, a) {
+				continue
+			}
+			t.reloader.Stop()
+			delete(m.targets, key)
+			level.Info(m.logger).Log("msg", "account target changed, rebuilding", "target", key.String())
+		}
+
+		productConf, err := m.exporterConf.GetProductConfig(key.Namespace)
+		if err != nil {
+			return err
+		}
+
+		// 每个账号使用独立凭证的 KscExporterConfig 副本传给 NewKscProductCollector,
+		// 意图是让它内部构建的 InstanceRepository 按账号隔离。下面 checkInstanceRepoOwner
+		// 对这一点做显式核对,而不是只在此处留一句断言。
+		acctConf := m.exporterConf.CloneForAccount(a.AccountID, a.AK, a.SK, a.Region)
+
+		c, err := NewKscProductCollector(key.Namespace, m.metricRepo, acctConf, &productConf, m.logger)
+		if err != nil {
+			return err
+		}
+		c.AccountID = a.AccountID
+		c.Region = a.Region
+		c.ExtraLabels = a.Labels
+
+		// c.InstanceRepo 是 instance.NewInstanceCache 每次调用都新分配的缓存包装,指针必然
+		// 各不相同,核对凭证隔离必须比较包装前的 c.RawInstanceRepo
+		if err := m.checkInstanceRepoOwner(c.RawInstanceRepo, a.AccountID); err != nil {
+			return err
+		}
+
+		reloadInterval := time.Duration(productConf.ReloadIntervalMinutes) * time.Minute
+		reloader := NewKscProductCollectorReloader(m.ctx, c, reloadInterval, m.logger)
+		go reloader.Run()
+
+		m.targets[key] = &accountTarget{collector: c, reloader: reloader, account: a}
+		level.Info(m.logger).Log("msg", "account target added", "target", key.String())
+	}
+
+	return nil
+}
+
+// checkInstanceRepoOwner 核对 repo 底层实例此前是否已经被另一个账号持有过。
+// instance.NewInstanceRepository 理应按账号构建互不共享的实例,但那是 instance 包自己的
+// 职责,调用方看不到其内部实现;这里用底层指针地址做运行时核对,一旦两个账号的
+// InstanceRepository 指向同一个底层实例就直接报错而不是静默放过,把"凭证不会跨租户
+// 复用"从注释里的断言变成启动期真正会失败的检查。无法取到地址的 repo 实现(值类型等)
+// 跳过核对,不影响主流程。
+func (m *MultiAccountCollector) checkInstanceRepoOwner(repo instance.InstanceRepository, accountID string) error {
+	if repo == nil {
+		return nil
+	}
+
+	v := reflect.ValueOf(repo)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return nil
+	}
+	ptr := v.Pointer()
+
+	m.instanceRepoOwnerLock.Lock()
+	defer m.instanceRepoOwnerLock.Unlock()
+
+	if owner, ok := m.instanceRepoOwner[ptr]; ok && owner != accountID {
+		return fmt.Errorf("instance repository shared between account %q and %q, refusing to start: credentials would leak across tenants", owner, accountID)
+	}
+	m.instanceRepoOwner[ptr] = accountID
+	return nil
+}
+
+// Describe 实现 prometheus.Collector;各 Namespace 的指标按运行时发现动态生成 Desc,
+// 与 KscProductCollector 保持一致,这里不做静态声明
+func (m *MultiAccountCollector) Describe(ch chan<- *prometheus.Desc) {}
+
+// Collect 实现 prometheus.Collector,并发收集每个账号目标的 series,
+// 补充 ksc_account、ksc_region 及账号自定义标签后合并写入同一个 /metrics 输出
+func (m *MultiAccountCollector) Collect(ch chan<- prometheus.Metric) {
+	m.lock.RLock()
+	targets := make([]*accountTarget, 0, len(m.targets))
+	for _, t := range m.targets {
+		targets = append(targets, t)
+	}
+	m.lock.RUnlock()
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(targets))
+	for _, t := range targets {
+		go func(t *accountTarget) {
+			defer wg.Done()
+
+			inner := make(chan prometheus.Metric)
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for pm := range inner {
+					ch <- withAccountLabels(pm, t.collector.AccountID, t.collector.Region, t.collector.ExtraLabels)
+				}
+			}()
+
+			if err := t.collector.Collect(inner); err != nil {
+				level.Error(m.logger).Log(
+					"msg", "collect account target fail",
+					"account", t.collector.AccountID,
+					"region", t.collector.Region,
+					"Namespace", t.collector.Namespace,
+					"err", err,
+				)
+			}
+			close(inner)
+			<-done
+		}(t)
+	}
+	wg.Wait()
+}
+
+// accountLabeledMetric 在原始 prometheus.Metric 的基础上追加账号身份标签,
+// 避免为此再重新声明一遍每个指标的 Desc
+type accountLabeledMetric struct {
+	orig      prometheus.Metric
+	accountID string
+	region    string
+	extra     map[string]string
+}
+
+func withAccountLabels(m prometheus.Metric, accountID, region string, extra map[string]string) prometheus.Metric {
+	return &accountLabeledMetric{orig: m, accountID: accountID, region: region, extra: extra}
+}
+
+func (m *accountLabeledMetric) Desc() *prometheus.Desc {
+	return m.orig.Desc()
+}
+
+func (m *accountLabeledMetric) Write(out *dto.Metric) error {
+	if err := m.orig.Write(out); err != nil {
+		return err
+	}
+
+	out.Label = append(out.Label,
+		&dto.LabelPair{Name: proto.String("ksc_account"), Value: proto.String(m.accountID)},
+		&dto.LabelPair{Name: proto.String("ksc_region"), Value: proto.String(m.region)},
+	)
+	for k, v := range m.extra {
+		out.Label = append(out.Label, &dto.LabelPair{Name: proto.String(k), Value: proto.String(v)})
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package collector
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"strings"
 	"sync"
 	"time"
@@ -24,14 +25,33 @@ type KscProductCollector struct {
 	Namespace    string
 	MetricRepo   metric.MetricRepository
 	InstanceRepo instance.InstanceRepository
-	MetricMap    map[string]*metric.Metric
-	InstanceMap  map[string]instance.KscInstance
-	Queries      metric.QuerySet
-	Conf         *config.KscExporterConfig
-	ProductConf  *config.KscProductConfig
-	handler      ProductHandler
-	logger       log.Logger
-	lock         sync.RWMutex
+	// RawInstanceRepo 是 InstanceRepo 被 instance.NewInstanceCache 包装前的底层实例,
+	// InstanceRepo 本身每次 NewKscProductCollector 调用都会生成一个新的缓存包装,其指针
+	// 必然各不相同,无法用来核对底层实例是否被跨账号复用;要核对凭证隔离,必须比较
+	// 这个未被包装过的指针。
+	RawInstanceRepo instance.InstanceRepository
+	MetricMap       map[string]*metric.Metric
+	InstanceMap     map[string]instance.KscInstance
+	Queries         metric.QuerySet
+	Conf            *config.KscExporterConfig
+	ProductConf     *config.KscProductConfig
+	handler         ProductHandler
+	logger          log.Logger
+	lock            sync.RWMutex
+
+	// reloadMu 序列化 LoadMetricsByProductConf 的并发调用:该方法以前只会被
+	// KscProductCollectorReloader 的定时 goroutine 串行调用,现在 `/-/reload` 也可能
+	// 在任意时刻从另一个 goroutine 触发同一个 namespace 的 reload,必须互斥,
+	// 否则方法开头不持有 c.lock 的 MetricMap 初始化,以及 loadMetrics 中先读后写
+	// MetricMap 的过程,会与并发调用发生竞争。
+	reloadMu sync.Mutex
+
+	// AccountID、Region 标识该采集器所属的账号与地域,用于多账号/多地域扇出场景下
+	// 在 /metrics 中区分同一产品下不同租户的序列。单账号部署时二者为空。
+	AccountID string
+	Region    string
+	// ExtraLabels 来自账号配置的附加标签覆盖,随 ksc_account、ksc_region 一并打到每条 series 上
+	ExtraLabels map[string]string
 }
 
 //GetMetrics
@@ -73,6 +93,18 @@ func (c *KscProductCollector) Collect(ch chan<- prometheus.Metric) (err error) {
 	return
 }
 
+// snapshotQueries 在持有读锁的情况下拷贝一份当前的 c.Queries,供 push 模式在不持有锁的
+// 情况下安全地做后续的 SplitByBatch/遍历,避免直接读 c.Queries 与 LoadMetricsByProductConf
+// 里加锁的写操作发生数据竞争
+func (c *KscProductCollector) snapshotQueries() metric.QuerySet {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	queries := make(metric.QuerySet, len(c.Queries))
+	copy(queries, c.Queries)
+	return queries
+}
+
 //LoadMetricsByMetricConf 指标纬度配置
 func (c *KscProductCollector) LoadMetricsByMetricConf() error {
 	if len(c.MetricMap) == 0 {
@@ -83,6 +115,9 @@ func (c *KscProductCollector) LoadMetricsByMetricConf() error {
 
 // 产品纬度配置
 func (c *KscProductCollector) LoadMetricsByProductConf() error {
+	// 防止定时 reloader 与 `/-/reload` 触发的按需 reload 并发执行同一个 namespace
+	c.reloadMu.Lock()
+	defer c.reloadMu.Unlock()
 
 	level.Info(c.logger).Log("msg", "start load metrics", "Namespace", c.Namespace)
 	if len(c.MetricMap) == 0 {
@@ -98,6 +133,16 @@ func (c *KscProductCollector) LoadMetricsByProductConf() error {
 		return err
 	}
 
+	// 多副本水平分片:每个副本只负责 xxhash(InstanceID) % ShardTotal == ShardIndex 的实例,
+	// 必须先于下面的数量截断生效,否则分片后仍可能因为截断而丢失实例
+	if c.Conf.ShardTotal > 1 {
+		var dropped int
+		instances, dropped = shardInstances(instances, c.Conf.ShardIndex, c.Conf.ShardTotal)
+		if dropped > 0 {
+			instancesDroppedTotal.WithLabelValues(c.Namespace, "shard").Add(float64(dropped))
+		}
+	}
+
 	if config.IsSupportMultiDimensionNamespace(c.Namespace) {
 		if len(instances) > config.DefaultSupportInstances {
 
@@ -110,6 +155,7 @@ func (c *KscProductCollector) LoadMetricsByProductConf() error {
 				config.DefaultSupportInstances,
 			)
 
+			instancesDroppedTotal.WithLabelValues(c.Namespace, "cap").Add(float64(len(instances) - config.DefaultSupportInstances))
 			instances = instances[:config.DefaultSupportInstances]
 		}
 	}
@@ -122,16 +168,27 @@ func (c *KscProductCollector) LoadMetricsByProductConf() error {
 	//加载查询
 	var numSeries int
 	currentTime := time.Now().Unix()
+	c.lock.RLock()
+	metrics := make([]*metric.Metric, 0, len(c.MetricMap))
 	for _, m := range c.MetricMap {
+		metrics = append(metrics, m)
+	}
+	c.lock.RUnlock()
+
+	var queries metric.QuerySet
+	for _, m := range metrics {
 		if currentTime-m.LoadTimeAt < 60 {
 			q, e := metric.NewQuery(m, c.MetricRepo)
 			if e != nil {
 				return e
 			}
-			c.Queries = append(c.Queries, q)
+			queries = append(queries, q)
 			numSeries += len(q.Metric.SeriesCache.Series)
 		}
 	}
+	c.lock.Lock()
+	c.Queries = append(c.Queries, queries...)
+	c.lock.Unlock()
 
 	level.Info(c.logger).Log("msg", "Init new query", "Namespace", c.Namespace, "metric_num", len(c.Queries), "new_series_num", numSeries)
 	return nil
@@ -150,52 +207,108 @@ func (c *KscProductCollector) loadMetrics(instances []instance.KscInstance) erro
 			excludeMetrics = append(excludeMetrics, strings.ToLower(em))
 		}
 	}
+	// 产品 handler 可选地声明了子资源(例如 Redis 分片、KRDS 只读实例),
+	// 此时每个实例下的子资源各自产出一份 Metric/Series,而不是整个实例合并成一条
+	subHandler, hasSubResources := c.handler.(SubResourceHandler)
+
 	for _, ins := range instances {
 		allMeta, err := c.MetricRepo.ListMetrics(c.Namespace, ins.GetInstanceID())
 		if err != nil {
 			level.Warn(c.logger).Log("msg", "request metric list fail", "err", err, "Namespace", c.Namespace, "InstanceId", ins.GetInstanceID())
 		}
 
+		var subResources []SubResource
+		if hasSubResources {
+			subResources, err = subHandler.GetSubResources(ins)
+			if err != nil {
+				level.Warn(c.logger).Log("msg", "get sub resources fail", "err", err, "Namespace", c.Namespace, "InstanceId", ins.GetInstanceID())
+			}
+		}
+
 		if len(allMeta) > 0 {
 			for _, meta := range allMeta {
 				if len(excludeMetrics) != 0 && util.IsStrInList(excludeMetrics, strings.ToLower(meta.MetricName)) {
 					continue
 				}
 
-				nm, err := c.createMetricWithMeta(meta, productConf, ins.GetInstanceID())
-				if err != nil {
-					level.Warn(c.logger).Log("msg", "Create metric fail", "err", err, "Namespace", c.Namespace, "name", meta.MetricName)
+				if len(subResources) > 0 {
+					for _, sub := range subResources {
+						c.loadMetricForTarget(meta, productConf, ins, &sub)
+					}
 					continue
 				}
 
-				c.lock.Lock()
-				key := fmt.Sprintf("%s.%s", meta.MetricName, ins.GetInstanceID())
-				c.MetricMap[key] = nm
-				c.lock.Unlock()
+				c.loadMetricForTarget(meta, productConf, ins, nil)
+			}
+		}
+	}
 
-				// 获取该指标下的所有实例纬度查询或自定义纬度查询
-				series, err := c.handler.GetSeriesByInstances(nm, []instance.KscInstance{ins})
+	return nil
+}
 
-				if err != nil {
-					level.Error(c.logger).Log("msg", "create metric series err", "err", err, "Namespace", c.Namespace, "name", meta.MetricName)
-				}
+// loadMetricForTarget 为 (instance, 可选的子资源) 组合创建/更新一条 Metric 及其 Series。
+// sub 为 nil 时就是原有的"一个实例一条 series"路径;非 nil 时 MetricMap 的 key 与
+// series 的纬度标签都会额外带上子资源标识,使同一实例下的多个子资源互不覆盖。
+func (c *KscProductCollector) loadMetricForTarget(meta *metric.Meta, productConf config.KscProductConfig, ins instance.KscInstance, sub *SubResource) {
+	key := fmt.Sprintf("%s.%s", meta.MetricName, ins.GetInstanceID())
+	if sub != nil {
+		key = fmt.Sprintf("%s.%s", key, sub.ID)
+	}
 
-				level.Debug(c.logger).Log("msg", "found remote instances", "count", len(series), "Namespace", c.Namespace, "name", meta.MetricName)
+	nm, err := c.createMetricWithMeta(meta, productConf, key)
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "Create metric fail", "err", err, "Namespace", c.Namespace, "name", meta.MetricName)
+		return
+	}
 
-				if err := nm.LoadSeries(series); err != nil {
-					level.Error(c.logger).Log("msg", "load metric series err", "err", err, "Namespace", c.Namespace, "name", meta.MetricName)
-				}
-			}
+	c.lock.Lock()
+	c.MetricMap[key] = nm
+	c.lock.Unlock()
+
+	// 获取该指标下的所有实例纬度查询或自定义纬度查询
+	series, err := c.handler.GetSeriesByInstances(nm, []instance.KscInstance{ins})
+	if err != nil {
+		level.Error(c.logger).Log("msg", "create metric series err", "err", err, "Namespace", c.Namespace, "name", meta.MetricName)
+	}
+
+	if sub != nil {
+		series = withSubResourceDimension(series, sub)
+	}
+
+	if len(productConf.RelabelConfigs) > 0 {
+		series, err = c.applyRelabelPipeline(series, ins, productConf.RelabelConfigs)
+		if err != nil {
+			level.Error(c.logger).Log("msg", "relabel pipeline err", "err", err, "Namespace", c.Namespace, "name", meta.MetricName)
+			return
 		}
 	}
 
-	return nil
+	level.Debug(c.logger).Log("msg", "found remote instances", "count", len(series), "Namespace", c.Namespace, "name", meta.MetricName)
+
+	if err := nm.LoadSeries(series); err != nil {
+		level.Error(c.logger).Log("msg", "load metric series err", "err", err, "Namespace", c.Namespace, "name", meta.MetricName)
+	}
 }
 
-func (c *KscProductCollector) createMetricWithMeta(meta *metric.Meta, productConf config.KscProductConfig, instanceId string) (*metric.Metric, error) {
+// withSubResourceDimension 给 series 追加子资源标识及其附加标签作为纬度,
+// 使 loadMetrics 产出的 series 带上额外的分片/只读实例等维度
+func withSubResourceDimension(series []*metric.Series, sub *SubResource) []*metric.Series {
+	for _, s := range series {
+		if s.Dimensions == nil {
+			s.Dimensions = make(map[string]string, len(sub.Labels)+1)
+		}
+		s.Dimensions[subResourceIDLabel] = sub.ID
+		for k, v := range sub.Labels {
+			s.Dimensions[k] = v
+		}
+	}
+	return series
+}
+
+// createMetricWithMeta 返回 MetricMap 中 key 对应的 Metric,不存在时按 meta/productConf 新建一个。
+// key 通常是 "metricName.instanceId",带子资源时还会追加子资源标识,由调用方统一构造。
+func (c *KscProductCollector) createMetricWithMeta(meta *metric.Meta, productConf config.KscProductConfig, key string) (*metric.Metric, error) {
 	c.lock.RLock()
-	key := fmt.Sprintf("%s.%s", meta.MetricName, instanceId)
-	// m, exists := c.MetricMap[meta.MetricName]
 	m, exists := c.MetricMap[key]
 	c.lock.RUnlock()
 
@@ -242,15 +355,26 @@ func (r *KscProductCollectorReloader) Run() {
 	ticker := time.NewTicker(r.reloadInterval)
 	defer ticker.Stop()
 
-	// sleep when first start
-	time.Sleep(r.reloadInterval)
+	// 首次启动在 [0, reloadInterval) 内随机错峰,避免所有 namespace 的 reloader
+	// 在进程启动的同一时刻一起向 KSC 监控接口发起请求
+	if r.reloadInterval > 0 {
+		jitter := time.Duration(rand.Int63n(int64(r.reloadInterval)))
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-time.After(jitter):
+		}
+	}
 
 	for {
+		start := time.Now()
 		level.Info(r.logger).Log("msg", "start reload product metadata", "Namespace", r.collector.Namespace)
 		e := r.reloadMetricsByProductConf()
 		if e != nil {
 			level.Error(r.logger).Log("msg", "reload product error", "err", e, "namespace", r.collector.Namespace)
 		}
+		reloadDurationSeconds.WithLabelValues(r.collector.Namespace).Set(time.Since(start).Seconds())
+		lastReloadTimestampSeconds.WithLabelValues(r.collector.Namespace).Set(float64(time.Now().Unix()))
 		level.Info(r.logger).Log("msg", "complete reload product metadata", "Namespace", r.collector.Namespace)
 		select {
 		case <-r.ctx.Done():
@@ -282,13 +406,17 @@ func NewKscProductCollector(
 	}
 
 	var instanceRepoCache instance.InstanceRepository
+	var rawInstanceRepo instance.InstanceRepository
 
 	if !util.IsStrInList(constant.NotSupportInstanceNamespaces, namespace) {
 		// 支持实例自动发现的产品
+		// exporterConf 携带当前账号的 AK/SK,交给 instance.NewInstanceRepository 构建该账号的底层实例;
+		// 多账号场景下由 MultiAccountCollector.checkInstanceRepoOwner 核对该实例没有被其他账号共用
 		instanceRepo, err := instance.NewInstanceRepository(namespace, exporterConf, logger)
 		if err != nil {
 			return nil, err
 		}
+		rawInstanceRepo = instanceRepo
 
 		// 使用instance缓存
 		reloadInterval := time.Duration(productConf.ReloadIntervalMinutes * int64(time.Minute))
@@ -296,18 +424,31 @@ func NewKscProductCollector(
 	}
 
 	c := &KscProductCollector{
-		Namespace:    namespace,
-		MetricRepo:   metricRepo,
-		InstanceRepo: instanceRepoCache,
-		Conf:         exporterConf,
-		ProductConf:  productConf,
-		logger:       logger,
+		Namespace:       namespace,
+		MetricRepo:      metricRepo,
+		InstanceRepo:    instanceRepoCache,
+		RawInstanceRepo: rawInstanceRepo,
+		Conf:            exporterConf,
+		ProductConf:     productConf,
+		logger:          logger,
+	}
+
+	if exporterConf.ShardTotal > 1 {
+		shardInfo.WithLabelValues(namespace).Set(float64(exporterConf.ShardIndex))
 	}
 
 	handler, err := factory(c, logger)
 	if err != nil {
 		return nil, err
 	}
+
+	if decorator, ok := handlerDecoratorMap[namespace]; ok {
+		handler, err = decorator(handler, c, logger)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	c.handler = handler
 
 	if err = c.LoadMetricsByMetricConf(); err != nil {
@@ -321,13 +462,20 @@ func NewKscProductCollector(
 	return c, nil
 }
 
-//NewKscProductCollectorReloader
+//NewKscProductCollectorReloader 构造一个按 collector.ProductConf.ReloadIntervalMinutes
+//自动刷新的 reloader;当该产品未配置 ReloadIntervalMinutes 时回退到 defaultReloadInterval,
+//以兼容尚未针对每个产品单独配置刷新周期的部署
 func NewKscProductCollectorReloader(
 	ctx context.Context,
 	collector *KscProductCollector,
-	reloadInterval time.Duration,
+	defaultReloadInterval time.Duration,
 	logger log.Logger,
 ) *KscProductCollectorReloader {
+	reloadInterval := defaultReloadInterval
+	if collector.ProductConf != nil && collector.ProductConf.ReloadIntervalMinutes > 0 {
+		reloadInterval = time.Duration(collector.ProductConf.ReloadIntervalMinutes) * time.Minute
+	}
+
 	childCtx, cancel := context.WithCancel(ctx)
 	reloader := &KscProductCollectorReloader{
 		collector:      collector,
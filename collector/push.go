@@ -0,0 +1,98 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"github.com/KscSDK/kingsoftcloud-exporter/config"
+	"github.com/KscSDK/kingsoftcloud-exporter/metric"
+	"github.com/KscSDK/kingsoftcloud-exporter/sink"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// KscProductPushRunner 是 Collect 拉取模式的推送替代:按固定周期把当前 c.Queries
+// 产生的样本批量推给配置的各个 Sink,复用与 Collect 完全相同的分批逻辑,
+// 这样拉、推两种模式在批大小、分批策略上不会出现行为差异
+type KscProductPushRunner struct {
+	collector *KscProductCollector
+	sinks     []sink.Sink
+	interval  time.Duration
+	ctx       context.Context
+	cancel    context.CancelFunc
+	logger    log.Logger
+}
+
+// NewKscProductPushRunner 构造一个按 interval 周期把 collector 的采集结果推送给 sinks 的 runner
+func NewKscProductPushRunner(
+	ctx context.Context,
+	collector *KscProductCollector,
+	sinks []sink.Sink,
+	interval time.Duration,
+	logger log.Logger,
+) *KscProductPushRunner {
+	childCtx, cancel := context.WithCancel(ctx)
+	return &KscProductPushRunner{
+		collector: collector,
+		sinks:     sinks,
+		interval:  interval,
+		ctx:       childCtx,
+		cancel:    cancel,
+		logger:    logger,
+	}
+}
+
+// Run 周期性地推送,直到 Stop 被调用
+func (r *KscProductPushRunner) Run() {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.pushOnce(); err != nil {
+			level.Error(r.logger).Log("msg", "push metrics fail", "Namespace", r.collector.Namespace, "err", err)
+		}
+
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop 停止推送循环
+func (r *KscProductPushRunner) Stop() {
+	r.cancel()
+}
+
+// pushOnce 复用 Collect 同样的 batchSize/SplitByBatch 逻辑取出一批样本,依次推送给每个 sink
+func (r *KscProductPushRunner) pushOnce() error {
+	c := r.collector
+
+	batchSize := config.DefaultQueryMetricBatchSize
+	if c.Namespace == "KS3" {
+		batchSize = config.DefaultKS3QueryMetricBatchSize
+	}
+
+	externalLabels := c.Conf.ExternalLabels
+
+	// 这里的 push 循环与 LoadMetricsByProductConf 的定时 reload 并发运行,必须用
+	// snapshotQueries 在锁保护下取一份拷贝,不能直接读 c.Queries,否则会在 reload 并发
+	// append 时触发 slice header 的数据竞争
+	for _, queries := range c.snapshotQueries().SplitByBatch(batchSize) {
+		pms, err := metric.GetPromMetricsByQueries(queries, r.logger)
+		if err != nil {
+			level.Error(r.logger).Log("msg", "get samples fail", "Namespace", c.Namespace, "err", err)
+			continue
+		}
+
+		for _, s := range r.sinks {
+			if err := s.Push(r.ctx, pms, externalLabels); err != nil {
+				level.Error(r.logger).Log("msg", "push to sink fail", "sink", s.Name(), "Namespace", c.Namespace, "err", err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package collector
+
+import (
+	"github.com/KscSDK/kingsoftcloud-exporter/config"
+	"github.com/KscSDK/kingsoftcloud-exporter/iam"
+	"github.com/KscSDK/kingsoftcloud-exporter/instance"
+	"github.com/KscSDK/kingsoftcloud-exporter/metric"
+)
+
+// instanceMetadata 是 instance.KscInstance 的可选扩展,暴露参与 relabel 的实例元数据。
+// 并非所有产品的实例类型都携带这些字段,未实现该接口的实例只参与 labels 中不依赖它们的规则。
+type instanceMetadata interface {
+	GetTags() map[string]string
+	GetVPCID() string
+	GetZone() string
+	GetProjectID() string
+}
+
+// instanceMetadataLabels 收集参与 relabel 的实例元数据标签:自定义 tag 以 tag_ 前缀展开,
+// vpc_id/zone 为实例拓扑信息,project_id 对应的项目名通过 iam.ReloadIAMProjects 缓存的
+// IAM 项目列表翻译成 project 标签
+func instanceMetadataLabels(ins instance.KscInstance) map[string]string {
+	labels := map[string]string{"instance_id": ins.GetInstanceID()}
+
+	meta, ok := ins.(instanceMetadata)
+	if !ok {
+		return labels
+	}
+
+	for k, v := range meta.GetTags() {
+		labels["tag_"+k] = v
+	}
+	labels["vpc_id"] = meta.GetVPCID()
+	labels["zone"] = meta.GetZone()
+
+	if projectID := meta.GetProjectID(); projectID != "" {
+		labels["project_id"] = projectID
+		if name, ok := iam.ProjectName(projectID); ok {
+			labels["project"] = name
+		}
+	}
+
+	return labels
+}
+
+// applyRelabelPipeline 对每条 series 执行 rules,labels 取实例元数据(tag、VPC、可用区、
+// IAM 项目名)与 series 自身纬度的并集;被 keep/drop 规则剔除的 series 不会进入返回结果,
+// replace/labelmap/labeldrop 命中的结果写回 series.Dimensions。规则求值本身由
+// config.ApplyRelabelPipeline 实现,这里只负责拼出 collector 纬度的 labels 输入。
+func (c *KscProductCollector) applyRelabelPipeline(series []*metric.Series, ins instance.KscInstance, rules []config.RelabelConfig) ([]*metric.Series, error) {
+	baseLabels := instanceMetadataLabels(ins)
+	kept := series[:0]
+
+	for _, s := range series {
+		merged := make(map[string]string, len(baseLabels)+len(s.Dimensions))
+		for k, v := range baseLabels {
+			merged[k] = v
+		}
+		for k, v := range s.Dimensions {
+			merged[k] = v
+		}
+
+		result, keep, err := config.ApplyRelabelPipeline(merged, rules)
+		if err != nil {
+			return nil, err
+		}
+		if !keep {
+			continue
+		}
+
+		s.Dimensions = result
+		kept = append(kept, s)
+	}
+
+	return kept, nil
+}
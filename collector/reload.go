@@ -0,0 +1,96 @@
+package collector
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+var (
+	// lastReloadTimestampSeconds 记录每个 namespace 的定时 reloader 最近一次 reload 结束的
+	// unix 时间,reload 出错时同样会更新;`/-/reload` 触发的按需 reload 不更新它。
+	// 配合告警规则可以发现长时间没有完成 reload(例如卡住)的 namespace
+	lastReloadTimestampSeconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ksc_exporter_last_reload_timestamp_seconds",
+		Help: "Unix timestamp of the last completed metadata reload for the namespace",
+	}, []string{"namespace"})
+
+	// reloadDurationSeconds 记录每次 reload 耗时,用于发现卡住或变慢的 reload
+	reloadDurationSeconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ksc_exporter_reload_duration_seconds",
+		Help: "Duration in seconds of the last metadata reload for the namespace",
+	}, []string{"namespace"})
+)
+
+func init() {
+	prometheus.MustRegister(lastReloadTimestampSeconds, reloadDurationSeconds)
+}
+
+// ReloadHandler 实现 `/-/reload` HTTP 接口,支持按 namespace 按需触发一次
+// LoadMetricsByProductConf,而不必等待该 namespace 自己的定时 reloader
+type ReloadHandler struct {
+	logger log.Logger
+
+	lock       sync.RWMutex
+	collectors map[string]*KscProductCollector
+}
+
+// NewReloadHandler 构造一个空的 ReloadHandler,调用方通过 Register 把各 namespace 的
+// collector 注册进来
+func NewReloadHandler(logger log.Logger) *ReloadHandler {
+	return &ReloadHandler{
+		logger:     logger,
+		collectors: make(map[string]*KscProductCollector),
+	}
+}
+
+// Register 把一个 namespace 的 collector 挂载到 reload 接口上
+func (h *ReloadHandler) Register(c *KscProductCollector) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.collectors[c.Namespace] = c
+}
+
+// RegisterRoutes 把 h 挂载到 mux 的 "/-/reload" 路径上,调用方(通常是进程 main 函数)
+// 在构造完 ReloadHandler 并 Register 完所有 namespace 的 collector 后调用一次即可
+func (h *ReloadHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.Handle("/-/reload", h)
+}
+
+// ServeHTTP 处理形如 `POST /-/reload?namespace=BWS` 的请求,触发指定 namespace 立即重新加载;
+// 不带 namespace 参数时对所有已注册的 namespace 都触发一次
+func (h *ReloadHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	namespace := req.URL.Query().Get("namespace")
+
+	h.lock.RLock()
+	var targets []*KscProductCollector
+	if namespace == "" {
+		for _, c := range h.collectors {
+			targets = append(targets, c)
+		}
+	} else if c, ok := h.collectors[namespace]; ok {
+		targets = append(targets, c)
+	}
+	h.lock.RUnlock()
+
+	if len(targets) == 0 {
+		http.Error(w, fmt.Sprintf("namespace %q not found", namespace), http.StatusNotFound)
+		return
+	}
+
+	for _, c := range targets {
+		if err := c.LoadMetricsByProductConf(); err != nil {
+			level.Error(h.logger).Log("msg", "on-demand reload fail", "Namespace", c.Namespace, "err", err)
+			http.Error(w, fmt.Sprintf("reload %s fail: %v", c.Namespace, err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, "ok")
+}
@@ -0,0 +1,63 @@
+package collector
+
+import (
+	"github.com/KscSDK/kingsoftcloud-exporter/config"
+	"github.com/KscSDK/kingsoftcloud-exporter/instance"
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/cespare/xxhash/v2"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// shardIndexFlag、shardTotalFlag 对应 --shard.index/--shard.total(或同名环境变量),
+	// 由调用方在 kingpin.Parse() 之后通过 ApplyShardFlags 写入 config.KscExporterConfig
+	shardIndexFlag = kingpin.Flag("shard.index", "Index of this exporter replica, 0-based, must be less than shard.total").
+			Envar("KSC_EXPORTER_SHARD_INDEX").Default("0").Int()
+	shardTotalFlag = kingpin.Flag("shard.total", "Total number of exporter replicas sharding instances between them, <=1 disables sharding").
+			Envar("KSC_EXPORTER_SHARD_TOTAL").Default("1").Int()
+
+	// shardInfo 暴露当前 exporter 副本所负责的分片编号,配合 ksc_exporter_shard_info{namespace}
+	// 可以确认分片配置在每个副本上确实生效
+	shardInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ksc_exporter_shard_info",
+		Help: "The shard index owned by this exporter replica for the given namespace, only meaningful when shard.total > 1",
+	}, []string{"namespace"})
+
+	// instancesDroppedTotal 统计因分片或 DefaultSupportInstances 截断而未被采集的实例数,
+	// reason 取值 "shard" 或 "cap",便于区分是分片路由丢弃的还是超出单产品实例上限被截断的
+	instancesDroppedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ksc_exporter_instances_dropped_total",
+		Help: "Total number of instances excluded from collection, either routed to another shard or truncated by the per-product instance cap",
+	}, []string{"namespace", "reason"})
+)
+
+func init() {
+	prometheus.MustRegister(shardInfo, instancesDroppedTotal)
+}
+
+// ApplyShardFlags 把 --shard.index/--shard.total(或对应环境变量)解析到的值写入 conf,
+// 调用方应当在 kingpin.Parse() 之后、构造任何 KscProductCollector 之前调用一次
+func ApplyShardFlags(conf *config.KscExporterConfig) {
+	conf.ShardIndex = *shardIndexFlag
+	conf.ShardTotal = *shardTotalFlag
+}
+
+// shardInstances 按 xxhash(InstanceID) % shardTotal 过滤实例,使 N 个 exporter 副本各自
+// 负责不相交的实例子集,返回本分片应采集的实例列表及被路由到其他分片而丢弃的数量
+func shardInstances(instances []instance.KscInstance, shardIndex, shardTotal int) ([]instance.KscInstance, int) {
+	if shardTotal <= 1 {
+		return instances, 0
+	}
+
+	kept := make([]instance.KscInstance, 0, len(instances))
+	var dropped int
+	for _, ins := range instances {
+		if int(xxhash.Sum64String(ins.GetInstanceID())%uint64(shardTotal)) == shardIndex {
+			kept = append(kept, ins)
+		} else {
+			dropped++
+		}
+	}
+
+	return kept, dropped
+}
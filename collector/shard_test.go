@@ -0,0 +1,72 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/KscSDK/kingsoftcloud-exporter/instance"
+)
+
+// fakeInstance 只关心 GetInstanceID,通过内嵌 nil 的 instance.KscInstance 满足接口其余方法,
+// 未用到的方法在测试中不会被调用到
+type fakeInstance struct {
+	instance.KscInstance
+	id string
+}
+
+func (f fakeInstance) GetInstanceID() string { return f.id }
+
+func TestShardInstancesPartitionsDisjointly(t *testing.T) {
+	const shardTotal = 4
+	instances := make([]instance.KscInstance, 0, 100)
+	for i := 0; i < 100; i++ {
+		instances = append(instances, fakeInstance{id: "ins-" + string(rune('a'+i%26)) + string(rune('0'+i/26))})
+	}
+
+	seen := make(map[string]int)
+	var totalKept, totalDropped int
+	for shardIndex := 0; shardIndex < shardTotal; shardIndex++ {
+		kept, dropped := shardInstances(instances, shardIndex, shardTotal)
+		totalKept += len(kept)
+		totalDropped += dropped
+		for _, ins := range kept {
+			seen[ins.GetInstanceID()]++
+		}
+	}
+
+	if totalKept+totalDropped != len(instances)*shardTotal {
+		t.Fatalf("kept+dropped across shards should account for every instance exactly once per shard")
+	}
+	for id, count := range seen {
+		if count != 1 {
+			t.Fatalf("instance %s was kept by %d shards, want exactly 1", id, count)
+		}
+	}
+	if len(seen) != len(instances) {
+		t.Fatalf("expected every instance to be owned by exactly one shard, got %d/%d", len(seen), len(instances))
+	}
+}
+
+func TestShardInstancesDisabledWhenShardTotalNotGreaterThanOne(t *testing.T) {
+	instances := []instance.KscInstance{fakeInstance{id: "a"}, fakeInstance{id: "b"}}
+
+	kept, dropped := shardInstances(instances, 0, 1)
+	if len(kept) != len(instances) || dropped != 0 {
+		t.Fatalf("shardTotal<=1 should be a no-op, got kept=%d dropped=%d", len(kept), dropped)
+	}
+
+	kept, dropped = shardInstances(instances, 0, 0)
+	if len(kept) != len(instances) || dropped != 0 {
+		t.Fatalf("shardTotal=0 should be a no-op, got kept=%d dropped=%d", len(kept), dropped)
+	}
+}
+
+func TestShardInstancesIsStableForSameInstanceID(t *testing.T) {
+	ins := []instance.KscInstance{fakeInstance{id: "stable-instance"}}
+
+	kept1, _ := shardInstances(ins, 2, 4)
+	kept2, _ := shardInstances(ins, 2, 4)
+
+	if len(kept1) != len(kept2) {
+		t.Fatalf("shardInstances should deterministically route the same InstanceID to the same shard")
+	}
+}
@@ -0,0 +1,123 @@
+// Package config 描述 exporter 进程级配置(KscExporterConfig)与单个产品纬度的配置
+// (KscProductConfig),collector 包基于它们决定采集哪些实例/指标、多久 reload 一次。
+package config
+
+import (
+	"fmt"
+	"sync"
+)
+
+const (
+	// DefaultQueryMetricBatchSize 是除 KS3 外的产品每批查询的指标数上限
+	DefaultQueryMetricBatchSize = 20
+	// DefaultKS3QueryMetricBatchSize KS3 的监控接口限流更严格,需要更小的批量
+	DefaultKS3QueryMetricBatchSize = 5
+	// DefaultSupportInstances 是支持多维度监控的产品单次最多加载的实例数
+	DefaultSupportInstances = 200
+)
+
+// multiDimensionNamespaces 是支持多维度监控项、需要做 DefaultSupportInstances 截断保护的产品
+var multiDimensionNamespaces = map[string]bool{
+	"BWS":  true,
+	"SLB":  true,
+	"RDS":  true,
+	"KCS":  true,
+	"KRDS": true,
+}
+
+// IsSupportMultiDimensionNamespace 判断 namespace 是否支持多维度监控项
+func IsSupportMultiDimensionNamespace(namespace string) bool {
+	return multiDimensionNamespaces[namespace]
+}
+
+// KscExporterConfig 是 exporter 进程级配置,包含访问 KSC OpenAPI 所需的凭证与地域,
+// 以及各产品的 KscProductConfig
+type KscExporterConfig struct {
+	AK     string
+	SK     string
+	Region string
+
+	// AccountID 标识该配置所属的账号,单账号部署时为空。多账号场景下由
+	// CloneForAccount 按账号生成互不共享的配置副本。
+	AccountID string
+
+	// ShardIndex、ShardTotal 控制当前副本在水平分片场景下负责的分片编号与分片总数,
+	// ShardTotal<=1 表示未启用分片
+	ShardIndex int
+	ShardTotal int
+
+	// ExternalLabels 在推送模式下附加到每一条对外发出的 series 上
+	ExternalLabels map[string]string
+
+	// Sinks 配置推送模式下使用的目的地,可以与 /metrics 端点共存;为空表示仅支持拉取模式
+	Sinks []SinkConfig `yaml:"sinks,omitempty"`
+
+	lock     sync.RWMutex
+	products map[string]*KscProductConfig
+}
+
+// SinkKind 标识一个 Sink 的具体实现
+type SinkKind string
+
+const (
+	SinkKindRemoteWrite SinkKind = "remote_write"
+	SinkKindOTLPHTTP    SinkKind = "otlp_http"
+)
+
+// SinkConfig 描述一个推送目的地,collector 包据此构造对应的 sink.Sink 实现
+type SinkConfig struct {
+	Kind SinkKind `yaml:"kind"`
+	URL  string   `yaml:"url"`
+	// IntervalSeconds 是该 sink 的推送周期配置项。BuildSinks 不读取它,推送周期由
+	// NewKscProductPushRunner 的 interval 参数决定,同一个 runner 下的全部 sink 共用
+	IntervalSeconds int64 `yaml:"interval_seconds,omitempty"`
+	// TimeoutSeconds 是单次推送请求的超时时间,不设置时由调用方回退到默认值
+	TimeoutSeconds int64 `yaml:"timeout_seconds,omitempty"`
+	// MaxRetries、InitialBackoffSeconds、MaxBackoffSeconds 对应 sink.RetryConfig,
+	// 均不设置时由调用方回退到 sink.DefaultRetryConfig
+	MaxRetries            int   `yaml:"max_retries,omitempty"`
+	InitialBackoffSeconds int64 `yaml:"initial_backoff_seconds,omitempty"`
+	MaxBackoffSeconds     int64 `yaml:"max_backoff_seconds,omitempty"`
+}
+
+// GetProductConfig 返回 namespace 对应的产品配置
+func (c *KscExporterConfig) GetProductConfig(namespace string) (KscProductConfig, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	conf, ok := c.products[namespace]
+	if !ok {
+		return KscProductConfig{}, fmt.Errorf("product config not found, Namespace=%s", namespace)
+	}
+	return *conf, nil
+}
+
+// CloneForAccount 基于当前配置派生出一份覆盖了账号凭证的副本,其余字段(各产品配置、
+// 分片)保持不变。多账号扇出场景下每个账号都使用各自的副本构建 KscProductCollector,
+// 确保凭证、InstanceRepository 互不共享。
+func (c *KscExporterConfig) CloneForAccount(accountID, ak, sk, region string) *KscExporterConfig {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return &KscExporterConfig{
+		AK:             ak,
+		SK:             sk,
+		Region:         region,
+		AccountID:      accountID,
+		ShardIndex:     c.ShardIndex,
+		ShardTotal:     c.ShardTotal,
+		ExternalLabels: c.ExternalLabels,
+		Sinks:          c.Sinks,
+		products:       c.products,
+	}
+}
+
+// KscProductConfig 是单个产品(namespace)纬度的配置
+type KscProductConfig struct {
+	// ExcludeMetrics 按小写子串匹配排除的指标名
+	ExcludeMetrics []string `yaml:"exclude_metrics,omitempty"`
+	// ReloadIntervalMinutes 该产品自己的实例/指标元数据 reload 周期
+	ReloadIntervalMinutes int64 `yaml:"reload_interval_minutes,omitempty"`
+	// RelabelConfigs 是基于实例元数据与指标自身标签的 relabel 规则流水线
+	RelabelConfigs []RelabelConfig `yaml:"relabel_configs,omitempty"`
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RelabelAction 对应 Prometheus relabel_config 里的 action,取值相同,方便熟悉 Prometheus
+// 配置的用户直接套用。未设置 action 时按 keep 处理,这一点与 Prometheus(默认 replace)不同
+type RelabelAction string
+
+const (
+	RelabelKeep      RelabelAction = "keep"
+	RelabelDrop      RelabelAction = "drop"
+	RelabelReplace   RelabelAction = "replace"
+	RelabelLabelMap  RelabelAction = "labelmap"
+	RelabelLabelDrop RelabelAction = "labeldrop"
+)
+
+const defaultRelabelSeparator = ";"
+
+// RelabelConfig 描述一条 relabel 规则。比现有仅支持子串匹配的 ExcludeMetrics 表达力
+// 强得多,可以按实例的任意元数据筛选/重写标签。
+type RelabelConfig struct {
+	SourceLabels []string      `yaml:"source_labels,omitempty"`
+	Separator    string        `yaml:"separator,omitempty"`
+	Regex        string        `yaml:"regex,omitempty"`
+	Action       RelabelAction `yaml:"action,omitempty"`
+	TargetLabel  string        `yaml:"target_label,omitempty"`
+	Replacement  string        `yaml:"replacement,omitempty"`
+
+	regex *regexp.Regexp
+}
+
+func (rc *RelabelConfig) compile() error {
+	if rc.regex != nil {
+		return nil
+	}
+
+	pattern := rc.Regex
+	if pattern == "" {
+		pattern = "(.*)"
+	}
+
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return fmt.Errorf("compile relabel regex %q fail: %w", rc.Regex, err)
+	}
+	rc.regex = re
+	return nil
+}
+
+// ApplyRelabelPipeline 依次执行 rules,对 labels(实例元数据与指标自身纬度的并集)做
+// keep/drop/replace/labelmap/labeldrop 变换。keep 为 false 表示命中了某条 keep/drop 规则,
+// 调用方应当丢弃整条 series;keep 为 true 时返回变换后应当使用的 labels。
+func ApplyRelabelPipeline(labels map[string]string, rules []RelabelConfig) (result map[string]string, keep bool, err error) {
+	if len(rules) == 0 {
+		return labels, true, nil
+	}
+
+	out := make(map[string]string, len(labels))
+	for k, v := range labels {
+		out[k] = v
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+		if err := rule.compile(); err != nil {
+			return nil, false, err
+		}
+
+		sep := rule.Separator
+		if sep == "" {
+			sep = defaultRelabelSeparator
+		}
+
+		values := make([]string, len(rule.SourceLabels))
+		for i, name := range rule.SourceLabels {
+			values[i] = out[name]
+		}
+		joined := strings.Join(values, sep)
+
+		replacement := rule.Replacement
+		if replacement == "" {
+			replacement = "$1"
+		}
+
+		switch rule.Action {
+		case RelabelDrop:
+			if rule.regex.MatchString(joined) {
+				return nil, false, nil
+			}
+		case RelabelKeep, "":
+			if !rule.regex.MatchString(joined) {
+				return nil, false, nil
+			}
+		case RelabelReplace:
+			match := rule.regex.FindStringSubmatch(joined)
+			if match == nil {
+				continue
+			}
+			if rule.TargetLabel == "" {
+				return nil, false, fmt.Errorf("relabel action replace requires target_label")
+			}
+			out[rule.TargetLabel] = expandReplacement(replacement, match)
+		case RelabelLabelMap:
+			// 对 out 当前内容做一份快照再遍历:下面的赋值会往 out 里插入新 key,Go 规范
+			// 没有定义遍历过程中新插入的 key 是否会被本次 range 访问到,若新 key 恰好也匹配
+			// regex 就会导致结果在多次运行间不确定。Prometheus 自己的 labelmap 实现也是
+			// 先固定一份快照再遍历,这里保持一致。
+			snapshot := make(map[string]string, len(out))
+			for k, v := range out {
+				snapshot[k] = v
+			}
+			for name, value := range snapshot {
+				match := rule.regex.FindStringSubmatch(name)
+				if match == nil {
+					continue
+				}
+				out[expandReplacement(replacement, match)] = value
+			}
+		case RelabelLabelDrop:
+			for name := range out {
+				if rule.regex.MatchString(name) {
+					delete(out, name)
+				}
+			}
+		default:
+			return nil, false, fmt.Errorf("unknown relabel action %q", rule.Action)
+		}
+	}
+
+	return out, true, nil
+}
+
+// replacementRefRe 匹配形如 $1、$12 的捕获组引用。必须按完整的数字序列整体解析,
+// 不能像字符串替换那样逐个数字处理,否则替换 $1 时会先吃掉 $12/$10 里的那个 "1"。
+var replacementRefRe = regexp.MustCompile(`\$(\d+)`)
+
+func expandReplacement(replacement string, match []string) string {
+	return replacementRefRe.ReplaceAllStringFunc(replacement, func(ref string) string {
+		idx, err := strconv.Atoi(ref[1:])
+		if err != nil || idx >= len(match) {
+			return ""
+		}
+		return match[idx]
+	})
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestApplyRelabelPipelineLabelMapIsDeterministic(t *testing.T) {
+	rules := []RelabelConfig{
+		{Action: RelabelLabelMap, Regex: "meta_(.+)"},
+	}
+
+	for i := 0; i < 20; i++ {
+		labels := map[string]string{
+			"meta_zone": "cn-beijing-a",
+			"meta_role": "master",
+			// 映射后得到的 meta_zone 本身也匹配 regex
+			"meta_meta_zone": "nested",
+		}
+
+		result, keep, err := ApplyRelabelPipeline(labels, rules)
+		if err != nil {
+			t.Fatalf("ApplyRelabelPipeline fail: %v", err)
+		}
+		if !keep {
+			t.Fatalf("expected keep=true")
+		}
+
+		if result["zone"] != "cn-beijing-a" || result["role"] != "master" {
+			t.Fatalf("run %d: unexpected result %#v", i, result)
+		}
+		// 本轮新写入的 meta_zone 不应该被当成本轮 labelmap 的输入再次展开、覆盖掉 zone
+		if result["meta_zone"] != "nested" || result["zone"] != "cn-beijing-a" {
+			t.Fatalf("run %d: labelmap re-processed a newly inserted key: %#v", i, result)
+		}
+	}
+}
+
+func TestApplyRelabelPipelineReplace(t *testing.T) {
+	rules := []RelabelConfig{
+		{
+			SourceLabels: []string{"region", "zone"},
+			Separator:    "/",
+			Regex:        "(.+)/(.+)",
+			Action:       RelabelReplace,
+			TargetLabel:  "location",
+			Replacement:  "$1-$2",
+		},
+	}
+
+	result, keep, err := ApplyRelabelPipeline(map[string]string{"region": "cn-beijing", "zone": "a"}, rules)
+	if err != nil {
+		t.Fatalf("ApplyRelabelPipeline fail: %v", err)
+	}
+	if !keep {
+		t.Fatalf("expected keep=true")
+	}
+	if result["location"] != "cn-beijing-a" {
+		t.Fatalf("unexpected location=%q", result["location"])
+	}
+}
+
+func TestApplyRelabelPipelineDrop(t *testing.T) {
+	rules := []RelabelConfig{
+		{SourceLabels: []string{"env"}, Regex: "test", Action: RelabelDrop},
+	}
+
+	_, keep, err := ApplyRelabelPipeline(map[string]string{"env": "test"}, rules)
+	if err != nil {
+		t.Fatalf("ApplyRelabelPipeline fail: %v", err)
+	}
+	if keep {
+		t.Fatalf("expected keep=false")
+	}
+}
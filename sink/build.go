@@ -0,0 +1,56 @@
+package sink
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/KscSDK/kingsoftcloud-exporter/config"
+)
+
+// defaultSinkTimeout 是 SinkConfig 未显式配置 TimeoutSeconds 时使用的请求超时
+const defaultSinkTimeout = 10 * time.Second
+
+// BuildSinks 把配置里的每个 config.SinkConfig 构造成对应的 Sink 实现,使 KscExporterConfig.Sinks
+// 真正可以在推送模式下被用起来,而不只是一个没有消费者的字段
+func BuildSinks(cfgs []config.SinkConfig) ([]Sink, error) {
+	sinks := make([]Sink, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		s, err := buildSink(cfg)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, s)
+	}
+	return sinks, nil
+}
+
+func buildSink(cfg config.SinkConfig) (Sink, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("sink %s: url is required", cfg.Kind)
+	}
+
+	timeout := defaultSinkTimeout
+	if cfg.TimeoutSeconds > 0 {
+		timeout = time.Duration(cfg.TimeoutSeconds) * time.Second
+	}
+
+	retry := DefaultRetryConfig
+	if cfg.MaxRetries > 0 {
+		retry.MaxRetries = cfg.MaxRetries
+	}
+	if cfg.InitialBackoffSeconds > 0 {
+		retry.InitialDelay = time.Duration(cfg.InitialBackoffSeconds) * time.Second
+	}
+	if cfg.MaxBackoffSeconds > 0 {
+		retry.MaxDelay = time.Duration(cfg.MaxBackoffSeconds) * time.Second
+	}
+
+	switch cfg.Kind {
+	case config.SinkKindRemoteWrite:
+		return NewRemoteWriteSink(cfg.URL, timeout, retry), nil
+	case config.SinkKindOTLPHTTP:
+		return NewOTLPHTTPSink(cfg.URL, timeout, retry), nil
+	default:
+		return nil, fmt.Errorf("unknown sink kind %q", cfg.Kind)
+	}
+}
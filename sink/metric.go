@@ -0,0 +1,123 @@
+package sink
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metricType 标识一条 sample 底层的 Prometheus 指标类型,remote-write 不区分类型,
+// OTLP 需要据此决定输出 Metric_Gauge 还是 Metric_Sum
+type metricType int
+
+const (
+	metricTypeGauge metricType = iota
+	metricTypeCounter
+)
+
+// sample 是把 prometheus.Metric 展平后的中间表示,remote-write、OTLP 两种 sink
+// 都从这个结构各自翻译成自己的线上格式,避免重复写两遍 Desc/Write 的解析逻辑
+type sample struct {
+	Name        string
+	Labels      map[string]string
+	Value       float64
+	TimestampMs int64
+	Type        metricType
+}
+
+// fqNameRe 从 prometheus.Desc.String() 形如 `Desc{fqName: "ksc_xxx", ...}` 的输出中
+// 取出指标名。client_golang 没有导出 Desc 的 fqName 字段,这是唯一不依赖内部 API 的取法。
+var fqNameRe = regexp.MustCompile(`fqName: "([^"]+)"`)
+
+func descName(desc *prometheus.Desc) string {
+	matches := fqNameRe.FindStringSubmatch(desc.String())
+	if len(matches) != 2 {
+		return ""
+	}
+	return matches[1]
+}
+
+// flatten 把一个 prometheus.Metric 转成一条或多条 sample。Counter/Gauge/Untyped 对应一条样本;
+// Histogram/Summary 按 Prometheus 线上格式展开成 _sum、_count 与各个 _bucket/_quantile。
+func flatten(m prometheus.Metric) ([]sample, error) {
+	var out dto.Metric
+	if err := m.Write(&out); err != nil {
+		return nil, fmt.Errorf("write metric fail: %w", err)
+	}
+
+	name := descName(m.Desc())
+	if name == "" {
+		return nil, fmt.Errorf("cannot resolve metric name from desc %s", m.Desc())
+	}
+
+	baseLabels := make(map[string]string, len(out.Label))
+	for _, lp := range out.Label {
+		baseLabels[lp.GetName()] = lp.GetValue()
+	}
+
+	ts := out.GetTimestampMs()
+
+	switch {
+	case out.Counter != nil:
+		return []sample{{Name: name, Labels: baseLabels, Value: out.Counter.GetValue(), TimestampMs: ts, Type: metricTypeCounter}}, nil
+	case out.Gauge != nil:
+		return []sample{{Name: name, Labels: baseLabels, Value: out.Gauge.GetValue(), TimestampMs: ts}}, nil
+	case out.Untyped != nil:
+		return []sample{{Name: name, Labels: baseLabels, Value: out.Untyped.GetValue(), TimestampMs: ts}}, nil
+	case out.Summary != nil:
+		samples := make([]sample, 0, len(out.Summary.Quantile)+2)
+		samples = append(samples,
+			sample{Name: name + "_sum", Labels: baseLabels, Value: out.Summary.GetSampleSum(), TimestampMs: ts},
+			sample{Name: name + "_count", Labels: baseLabels, Value: float64(out.Summary.GetSampleCount()), TimestampMs: ts},
+		)
+		for _, q := range out.Summary.Quantile {
+			samples = append(samples, sample{
+				Name:       name,
+				Labels:     withLabel(baseLabels, "quantile", fmt.Sprintf("%g", q.GetQuantile())),
+				Value:      q.GetValue(),
+				TimestampMs: ts,
+			})
+		}
+		return samples, nil
+	case out.Histogram != nil:
+		samples := make([]sample, 0, len(out.Histogram.Bucket)+2)
+		samples = append(samples,
+			sample{Name: name + "_sum", Labels: baseLabels, Value: out.Histogram.GetSampleSum(), TimestampMs: ts},
+			sample{Name: name + "_count", Labels: baseLabels, Value: float64(out.Histogram.GetSampleCount()), TimestampMs: ts},
+		)
+		for _, b := range out.Histogram.Bucket {
+			samples = append(samples, sample{
+				Name:       name + "_bucket",
+				Labels:     withLabel(baseLabels, "le", fmt.Sprintf("%g", b.GetUpperBound())),
+				Value:      float64(b.GetCumulativeCount()),
+				TimestampMs: ts,
+			})
+		}
+		return samples, nil
+	default:
+		return nil, fmt.Errorf("metric %s has no recognized value type", name)
+	}
+}
+
+func withLabel(labels map[string]string, k, v string) map[string]string {
+	out := make(map[string]string, len(labels)+1)
+	for lk, lv := range labels {
+		out[lk] = lv
+	}
+	out[k] = v
+	return out
+}
+
+// sortedLabelNames 返回 labels 的 key 按字典序排序后的结果,remote-write/OTLP 均要求
+// series 具有确定性的标签顺序
+func sortedLabelNames(labels map[string]string) []string {
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,60 @@
+package sink
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestFlattenCounter(t *testing.T) {
+	c := prometheus.NewCounter(prometheus.CounterOpts{Name: "ksc_test_total", Help: "test"})
+	c.Add(3)
+
+	samples, err := flatten(c)
+	if err != nil {
+		t.Fatalf("flatten fail: %v", err)
+	}
+	if len(samples) != 1 {
+		t.Fatalf("expected 1 sample, got %d", len(samples))
+	}
+	if samples[0].Name != "ksc_test_total" || samples[0].Value != 3 {
+		t.Fatalf("unexpected sample %#v", samples[0])
+	}
+	if samples[0].Type != metricTypeCounter {
+		t.Fatalf("expected metricTypeCounter, got %v", samples[0].Type)
+	}
+}
+
+func TestFlattenGauge(t *testing.T) {
+	g := prometheus.NewGauge(prometheus.GaugeOpts{Name: "ksc_test_gauge", Help: "test"})
+	g.Set(42)
+
+	samples, err := flatten(g)
+	if err != nil {
+		t.Fatalf("flatten fail: %v", err)
+	}
+	if len(samples) != 1 || samples[0].Value != 42 {
+		t.Fatalf("unexpected samples %#v", samples)
+	}
+	if samples[0].Type != metricTypeGauge {
+		t.Fatalf("expected metricTypeGauge, got %v", samples[0].Type)
+	}
+}
+
+func TestFlattenHistogram(t *testing.T) {
+	h := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "ksc_test_hist",
+		Help:    "test",
+		Buckets: []float64{1, 2, 5},
+	})
+	h.Observe(1.5)
+
+	samples, err := flatten(h)
+	if err != nil {
+		t.Fatalf("flatten fail: %v", err)
+	}
+	// _sum, _count, 加上每个 bucket 各一条
+	if len(samples) != 2+3 {
+		t.Fatalf("expected 5 samples, got %d: %#v", len(samples), samples)
+	}
+}
@@ -0,0 +1,166 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	collectormetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+// OTLPHTTPSink 把样本编码成 OTLP ExportMetricsServiceRequest,通过 OTLP/HTTP
+// (POST .../v1/metrics, content-type application/x-protobuf)推送给 collector
+type OTLPHTTPSink struct {
+	url        string
+	httpClient *http.Client
+	retry      RetryConfig
+}
+
+// NewOTLPHTTPSink 构造一个指向 url 的 OTLP/HTTP sink,url 应当是完整的
+// .../v1/metrics 路径
+func NewOTLPHTTPSink(url string, timeout time.Duration, retry RetryConfig) *OTLPHTTPSink {
+	return &OTLPHTTPSink{
+		url:        url,
+		httpClient: &http.Client{Timeout: timeout},
+		retry:      retry,
+	}
+}
+
+// Name 实现 Sink
+func (s *OTLPHTTPSink) Name() string {
+	return fmt.Sprintf("otlp_http(%s)", s.url)
+}
+
+// Push 实现 Sink,每个 prometheus.Metric 被展开为若干 NumberDataPoint,
+// 统一挂在一个不带 Resource 属性的 ScopeMetrics 下,externalLabels 作为每个数据点的 attributes
+func (s *OTLPHTTPSink) Push(ctx context.Context, metrics []prometheus.Metric, externalLabels map[string]string) error {
+	byName := make(map[string]*metricspb.Metric)
+	var order []string
+
+	for _, m := range metrics {
+		samples, err := flatten(m)
+		if err != nil {
+			// 跳过这一个翻译失败的指标,不要因此丢掉整批已经翻译好的样本,
+			// 与 Collect 对单个 query 失败的容忍方式保持一致
+			continue
+		}
+
+		for _, sp := range samples {
+			mt, ok := byName[sp.Name]
+			if !ok {
+				mt = newOTLPMetric(sp)
+				byName[sp.Name] = mt
+				order = append(order, sp.Name)
+			}
+
+			dp := toNumberDataPoint(sp, externalLabels)
+			switch data := mt.Data.(type) {
+			case *metricspb.Metric_Sum:
+				data.Sum.DataPoints = append(data.Sum.DataPoints, dp)
+			default:
+				gauge := mt.GetGauge()
+				gauge.DataPoints = append(gauge.DataPoints, dp)
+			}
+		}
+	}
+
+	if len(order) == 0 {
+		return nil
+	}
+
+	scopeMetrics := &metricspb.ScopeMetrics{}
+	for _, name := range order {
+		scopeMetrics.Metrics = append(scopeMetrics.Metrics, byName[name])
+	}
+
+	req := &collectormetricspb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricspb.ResourceMetrics{
+			{ScopeMetrics: []*metricspb.ScopeMetrics{scopeMetrics}},
+		},
+	}
+
+	data, err := proto.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("marshal otlp export request fail: %w", err)
+	}
+
+	return withRetry(ctx, s.retry, func() error {
+		return s.send(ctx, data)
+	})
+}
+
+func (s *OTLPHTTPSink) send(ctx context.Context, body []byte) error {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-protobuf")
+
+	resp, err := s.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("otlp export request fail: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("otlp export rejected with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
+// newOTLPMetric 为 sp 构造一个空的 OTLP Metric 容器:Counter 对应 Metric_Sum(单调递增、
+// 累计语义),其余类型沿用此前的 Metric_Gauge,否则下游按 OTLP 语义做 rate()/reset 检测时
+// 会把 Counter 误当成可以任意升降的 Gauge 处理
+func newOTLPMetric(sp sample) *metricspb.Metric {
+	if sp.Type == metricTypeCounter {
+		return &metricspb.Metric{
+			Name: sp.Name,
+			Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+				IsMonotonic:            true,
+				AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+			}},
+		}
+	}
+	return &metricspb.Metric{
+		Name: sp.Name,
+		Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{}},
+	}
+}
+
+func toNumberDataPoint(sp sample, externalLabels map[string]string) *metricspb.NumberDataPoint {
+	labels := make(map[string]string, len(sp.Labels)+len(externalLabels))
+	for k, v := range sp.Labels {
+		labels[k] = v
+	}
+	for k, v := range externalLabels {
+		labels[k] = v
+	}
+
+	dp := &metricspb.NumberDataPoint{
+		Value: &metricspb.NumberDataPoint_AsDouble{AsDouble: sp.Value},
+	}
+
+	timestampMs := sp.TimestampMs
+	if timestampMs == 0 {
+		timestampMs = time.Now().UnixMilli()
+	}
+	dp.TimeUnixNano = uint64(timestampMs) * uint64(time.Millisecond)
+
+	for _, name := range sortedLabelNames(labels) {
+		dp.Attributes = append(dp.Attributes, &commonpb.KeyValue{
+			Key:   name,
+			Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: labels[name]}},
+		})
+	}
+
+	return dp
+}
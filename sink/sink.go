@@ -0,0 +1,73 @@
+//Package sink 实现 exporter 的推送模式:把已经采集到的 prometheus.Metric 样本
+//主动推送到 Prometheus remote-write 或 OTLP/HTTP 后端,供 Prometheus 无法直接抓取
+//该 exporter(例如跨 VPC 边界)的场景使用
+package sink
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Sink 是一种推送目的地,Push 应当是幂等的、可安全重试的
+type Sink interface {
+	// Name 用于日志与错误信息中标识该 sink
+	Name() string
+	// Push 把本批次采集到的样本发送出去,externalLabels 会被附加到每一条 series 上
+	Push(ctx context.Context, metrics []prometheus.Metric, externalLabels map[string]string) error
+}
+
+// RetryConfig 描述单个 sink 的重试/退避策略
+type RetryConfig struct {
+	MaxRetries   int
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+}
+
+// DefaultRetryConfig 是未显式配置时使用的保守退避策略
+var DefaultRetryConfig = RetryConfig{
+	MaxRetries:   3,
+	InitialDelay: time.Second,
+	MaxDelay:     30 * time.Second,
+}
+
+// withRetry 按指数退避重试 fn,直到成功或达到 MaxRetries
+func withRetry(ctx context.Context, rc RetryConfig, fn func() error) error {
+	delay := rc.InitialDelay
+	if delay <= 0 {
+		delay = DefaultRetryConfig.InitialDelay
+	}
+	maxRetries := rc.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = DefaultRetryConfig.MaxRetries
+	}
+
+	var err error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+
+		if attempt == maxRetries {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		maxDelay := rc.MaxDelay
+		if maxDelay <= 0 {
+			maxDelay = DefaultRetryConfig.MaxDelay
+		}
+		if delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+
+	return err
+}